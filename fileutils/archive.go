@@ -0,0 +1,277 @@
+package fileutils
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ArchiveFormat selects the container format CopypathFromArchive should
+// expect from its io.Reader.
+type ArchiveFormat int
+
+const (
+	// ArchiveFormatTarGz is a gzip-compressed tar archive, as produced by
+	// `go mod download` and VCS host codeload URLs (e.g. GitHub's
+	// /archive/<ref>.tar.gz).
+	ArchiveFormatTarGz ArchiveFormat = iota
+	// ArchiveFormatZip is a zip archive, as produced by GitHub's
+	// /archive/<ref>.zip and codeload zip URLs.
+	ArchiveFormatZip
+)
+
+// CopypathFromArchive extracts the Go-relevant files from an archive
+// directly into dst, applying the same filtering Copypath does, without
+// writing the archive or its extracted contents to disk first. It operates
+// against DefaultFS, i.e. the host filesystem.
+//
+// If every entry in the archive shares a common top-level directory (as
+// GitHub's codeload archives do, naming it "owner-repo-sha/"), that
+// directory is stripped so dst ends up holding the package contents
+// directly. Entries that would escape dst, via "..", an absolute path, or a
+// symlink pointing outside dst, are rejected.
+func CopypathFromArchive(dst string, r io.Reader, format ArchiveFormat, tests bool) error {
+	return CopypathFromArchiveFS(DefaultFS, dst, r, format, tests)
+}
+
+// CopypathFromArchiveFS is like CopypathFromArchive but operates against fs.
+func CopypathFromArchiveFS(fs FS, dst string, r io.Reader, format ArchiveFormat, tests bool) error {
+	var err error
+	switch format {
+	case ArchiveFormatTarGz:
+		err = copyTarGzArchive(fs, dst, r, tests)
+	case ArchiveFormatZip:
+		err = copyZipArchive(fs, dst, r, tests)
+	default:
+		err = fmt.Errorf("copypathfromarchive: unknown archive format %v", format)
+	}
+	if err != nil {
+		RemoveAllFS(fs, dst)
+	}
+	return err
+}
+
+func copyTarGzArchive(fs FS, dst string, r io.Reader, tests bool) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("copypathfromarchive: gzip: %v", err)
+	}
+	defer gz.Close()
+
+	// tar has no central directory, so a stripped common prefix can only
+	// be computed by reading the whole thing up front. Buffering the
+	// decompressed bytes keeps everything in memory rather than on disk.
+	buf, err := io.ReadAll(gz)
+	if err != nil {
+		return fmt.Errorf("copypathfromarchive: read: %v", err)
+	}
+
+	var names []string
+	tr := tar.NewReader(bytes.NewReader(buf))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("copypathfromarchive: tar: %v", err)
+		}
+		// git-generated archives (GitHub's included) start with a
+		// "pax_global_header" entry that sits outside the common directory.
+		if hdr.Typeflag == tar.TypeDir || hdr.Typeflag == tar.TypeXGlobalHeader {
+			continue
+		}
+		names = append(names, hdr.Name)
+	}
+	prefix := commonArchivePrefix(names)
+
+	tr = tar.NewReader(bytes.NewReader(buf))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("copypathfromarchive: tar: %v", err)
+		}
+
+		name := stripArchivePrefix(hdr.Name, prefix)
+		if name == "" || hdr.Typeflag == tar.TypeDir {
+			continue
+		}
+		if pathExcluded(name, tests) || !isGoFile(name) {
+			continue
+		}
+
+		entryDst, err := safeJoin(dst, name)
+		if err != nil {
+			return fmt.Errorf("copypathfromarchive: %v", err)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeReg:
+			if err := writeFile(fs, entryDst, tr); err != nil {
+				return fmt.Errorf("copypathfromarchive: %v", err)
+			}
+		case tar.TypeSymlink:
+			if err := safeSymlink(fs, dst, entryDst, hdr.Linkname); err != nil {
+				return fmt.Errorf("copypathfromarchive: %v", err)
+			}
+		}
+	}
+	return nil
+}
+
+func copyZipArchive(fs FS, dst string, r io.Reader, tests bool) error {
+	buf, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("copypathfromarchive: read: %v", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf), int64(len(buf)))
+	if err != nil {
+		return fmt.Errorf("copypathfromarchive: zip: %v", err)
+	}
+
+	var names []string
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		names = append(names, f.Name)
+	}
+	prefix := commonArchivePrefix(names)
+
+	for _, f := range zr.File {
+		name := stripArchivePrefix(f.Name, prefix)
+		if name == "" || f.FileInfo().IsDir() {
+			continue
+		}
+		if pathExcluded(name, tests) || !isGoFile(name) {
+			continue
+		}
+
+		entryDst, err := safeJoin(dst, name)
+		if err != nil {
+			return fmt.Errorf("copypathfromarchive: %v", err)
+		}
+
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("copypathfromarchive: open(%q): %v", f.Name, err)
+		}
+		if f.Mode()&os.ModeSymlink != 0 {
+			target, err := io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				return fmt.Errorf("copypathfromarchive: read symlink(%q): %v", f.Name, err)
+			}
+			if err := safeSymlink(fs, dst, entryDst, string(target)); err != nil {
+				return fmt.Errorf("copypathfromarchive: %v", err)
+			}
+			continue
+		}
+		err = writeFile(fs, entryDst, rc)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("copypathfromarchive: %v", err)
+		}
+	}
+	return nil
+}
+
+func writeFile(fs FS, dst string, r io.Reader) error {
+	if err := mkdir(fs, filepath.Dir(dst)); err != nil {
+		return fmt.Errorf("mkdirall: %v", err)
+	}
+	w, err := fs.Create(dst)
+	if err != nil {
+		return fmt.Errorf("create(%q): %v", dst, err)
+	}
+	defer w.Close()
+	_, err = io.Copy(w, r)
+	return err
+}
+
+func safeSymlink(fs FS, dst, entryDst, target string) error {
+	if filepath.IsAbs(target) {
+		return fmt.Errorf("symlink %q has absolute target %q", entryDst, target)
+	}
+	resolved := filepath.Join(filepath.Dir(entryDst), target)
+	rel, err := filepath.Rel(filepath.Clean(dst), resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("symlink %q target %q escapes destination", entryDst, target)
+	}
+	if err := mkdir(fs, filepath.Dir(entryDst)); err != nil {
+		return fmt.Errorf("mkdirall: %v", err)
+	}
+	return fs.Symlink(target, entryDst)
+}
+
+// pathExcluded reports whether any component of name, an archive entry's
+// path, is excluded from vendoring. Unlike a filesystem walk, an archive
+// lists every file's full path directly, so the skipEntry check that a
+// directory walk normally applies per-directory has to be applied to every
+// path component here instead.
+func pathExcluded(name string, tests bool) bool {
+	parts := strings.Split(name, "/")
+	for i, part := range parts {
+		isDir := i < len(parts)-1
+		if skip, _ := skipEntry(part, isDir, tests); skip {
+			return true
+		}
+	}
+	return false
+}
+
+// safeJoin joins name onto dst, rejecting names that would escape dst via
+// ".." or an absolute path.
+func safeJoin(dst, name string) (string, error) {
+	if filepath.IsAbs(name) {
+		return "", fmt.Errorf("entry %q has an absolute path", name)
+	}
+	clean := filepath.Clean(name)
+	if clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("entry %q escapes destination", name)
+	}
+	return filepath.Join(dst, clean), nil
+}
+
+// commonArchivePrefix returns the shared top-level directory of names (e.g.
+// "owner-repo-sha"), or "" if there is no single directory common to all of
+// them.
+func commonArchivePrefix(names []string) string {
+	prefix := ""
+	for i, name := range names {
+		name = strings.TrimPrefix(name, "./")
+		parts := strings.SplitN(strings.TrimSuffix(name, "/"), "/", 2)
+		if len(parts) < 2 || parts[0] == "" {
+			return ""
+		}
+		if i == 0 {
+			prefix = parts[0]
+		} else if parts[0] != prefix {
+			return ""
+		}
+	}
+	return prefix
+}
+
+// stripArchivePrefix removes prefix (as computed by commonArchivePrefix)
+// from name, returning "" if name is the prefix directory entry itself.
+func stripArchivePrefix(name string, prefix string) string {
+	name = strings.TrimPrefix(name, "./")
+	if prefix == "" {
+		return name
+	}
+	name = strings.TrimPrefix(name, prefix+"/")
+	if name == prefix || name == prefix+"/" {
+		return ""
+	}
+	return name
+}
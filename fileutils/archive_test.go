@@ -0,0 +1,84 @@
+package fileutils
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"testing"
+)
+
+func buildTarGz(t *testing.T, entries map[string]string, dirs []string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for _, dir := range dirs {
+		if err := tw.WriteHeader(&tar.Header{Name: dir, Typeflag: tar.TypeDir, Mode: 0755}); err != nil {
+			t.Fatalf("tar dir header: %v", err)
+		}
+	}
+	for name, content := range entries {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content))}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatalf("tar header: %v", err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatalf("tar write: %v", err)
+		}
+	}
+	tw.Close()
+	gw.Close()
+	return buf.Bytes()
+}
+
+// A real GitHub codeload tar.gz emits an explicit directory header for the
+// top-level "owner-repo-sha/" folder in addition to the files beneath it;
+// that header must not prevent the prefix from being detected and stripped.
+func TestCopypathFromArchiveFSStripsPrefixWithDirectoryHeader(t *testing.T) {
+	data := buildTarGz(t, map[string]string{
+		"owner-repo-abc123/foo.go": "package pkg",
+	}, []string{"owner-repo-abc123/"})
+
+	fs := NewMemFS()
+	if err := CopypathFromArchiveFS(fs, "/vendor/pkg", bytes.NewReader(data), ArchiveFormatTarGz, false); err != nil {
+		t.Fatalf("CopypathFromArchiveFS: %v", err)
+	}
+	if _, err := fs.Stat("/vendor/pkg/foo.go"); err != nil {
+		t.Fatalf("expected the owner-repo-abc123 prefix to be stripped: %v", err)
+	}
+}
+
+func TestCopypathFromArchiveFSRejectsAbsolutePaths(t *testing.T) {
+	data := buildTarGz(t, map[string]string{
+		"/etc/evil.go": "package evil",
+	}, nil)
+
+	fs := NewMemFS()
+	err := CopypathFromArchiveFS(fs, "/vendor/pkg", bytes.NewReader(data), ArchiveFormatTarGz, false)
+	if err == nil {
+		t.Fatal("expected an error extracting an absolute-path entry")
+	}
+	if _, statErr := fs.Stat("/etc/evil.go"); statErr == nil {
+		t.Fatal("absolute-path entry must not have been written outside dst")
+	}
+}
+
+func TestCopypathFromArchiveFSZipStripsPrefix(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("owner-repo-abc123/foo.go")
+	if err != nil {
+		t.Fatalf("zip create: %v", err)
+	}
+	w.Write([]byte("package pkg"))
+	zw.Close()
+
+	fs := NewMemFS()
+	if err := CopypathFromArchiveFS(fs, "/vendor/pkg", bytes.NewReader(buf.Bytes()), ArchiveFormatZip, false); err != nil {
+		t.Fatalf("CopypathFromArchiveFS: %v", err)
+	}
+	if _, err := fs.Stat("/vendor/pkg/foo.go"); err != nil {
+		t.Fatalf("expected the owner-repo-abc123 prefix to be stripped: %v", err)
+	}
+}
@@ -0,0 +1,234 @@
+package fileutils
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// https://golang.org/cmd/go/#hdr-File_types
+var goFileTypes = []string{
+	".go",
+	".c", ".h",
+	".cc", ".cpp", ".cxx", ".hh", ".hpp", ".hxx",
+	".m",
+	".s", ".S",
+	".swig", ".swigcxx",
+	".syso",
+}
+
+// maxOpenCopies bounds the number of file copies in flight at once, across
+// all concurrent Copypath/CopypathFS calls, so a large vendor tree can't blow
+// past the process's open-file limit. Each copy holds a source and a
+// destination file open, so up to twice this many files are open.
+const maxOpenCopies = 128
+
+var copySem = make(chan struct{}, maxOpenCopies)
+
+// Copypath copies the contents of src to dst, excluding any file that is not
+// relevant to the Go compiler. It operates against DefaultFS, i.e. the host
+// filesystem.
+func Copypath(dst string, src string, tests bool) error {
+	return CopypathFS(DefaultFS, dst, src, tests)
+}
+
+// copyTask is one file or symlink discovered under src that needs to be
+// copied to dst.
+type copyTask struct {
+	dst, src string
+	symlink  bool
+	index    int
+}
+
+// CopypathFS is like Copypath but walks and copies through fs instead of the
+// host filesystem directly, so the same logic can be exercised against an
+// in-memory tree (MemFS) or another FS implementation.
+//
+// It walks src once to enumerate the files to copy, then copies them
+// concurrently across a pool of runtime.NumCPU() workers. Errors from
+// individual files are collected and, if any occurred, the partial copy at
+// dst is removed once at the end rather than after each failure.
+func CopypathFS(fs FS, dst string, src string, tests bool) error {
+	tasks, err := enumerateCopyTasks(fs, dst, src, tests)
+	if err != nil {
+		RemoveAllFS(fs, dst)
+		return err
+	}
+	if err := runCopyTasks(fs, tasks); err != nil {
+		RemoveAllFS(fs, dst)
+		return err
+	}
+	return nil
+}
+
+// skipEntry reports whether name (a base name) is excluded from vendoring
+// altogether, and if so whether, being a directory, its whole subtree
+// should be skipped too.
+//
+// https://golang.org/cmd/go/#hdr-Description_of_package_lists
+func skipEntry(name string, isDir bool, tests bool) (skip bool, skipDir bool) {
+	if strings.HasPrefix(name, ".") ||
+		(strings.HasPrefix(name, "_") && name != "_testdata") ||
+		(!tests && name == "_testdata") ||
+		(!tests && name == "testdata") ||
+		(!tests && strings.HasSuffix(name, "_test.go")) {
+		return true, isDir
+	}
+	return false, false
+}
+
+// isGoFile reports whether name has a file extension relevant to the Go
+// compiler (see goFileTypes).
+func isGoFile(name string) bool {
+	for _, ext := range goFileTypes {
+		if strings.HasSuffix(name, ext) {
+			return true
+		}
+	}
+	return false
+}
+
+func enumerateCopyTasks(fs FS, dst string, src string, tests bool) ([]copyTask, error) {
+	var tasks []copyTask
+	err := walk(fs, src, func(path string, info os.FileInfo) error {
+		name := filepath.Base(path)
+		if skip, skipDir := skipEntry(name, info.IsDir(), tests); skip {
+			if skipDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		if !isGoFile(name) {
+			return nil
+		}
+
+		tasks = append(tasks, copyTask{
+			dst:     filepath.Join(dst, path[len(src):]),
+			src:     path,
+			symlink: info.Mode()&os.ModeSymlink != 0,
+		})
+		return nil
+	})
+	return tasks, err
+}
+
+// runCopyTasks copies every task through a pool of runtime.NumCPU() workers,
+// bounded by the package-wide copySem so concurrent Copypath calls don't
+// exhaust the process's open-file limit. Every task runs to completion even
+// if earlier ones failed; all resulting errors are joined together.
+func runCopyTasks(fs FS, tasks []copyTask) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	workers := runtime.NumCPU()
+	if workers > len(tasks) {
+		workers = len(tasks)
+	}
+
+	taskCh := make(chan copyTask)
+	errs := make([]error, len(tasks))
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for t := range taskCh {
+				errs[t.index] = copyTaskFile(fs, t)
+			}
+		}()
+	}
+	for i, t := range tasks {
+		t.index = i
+		taskCh <- t
+	}
+	close(taskCh)
+	wg.Wait()
+
+	return joinErrors(errs)
+}
+
+// multiError is the error returned when more than one copy task fails; its
+// Error lists every underlying error on its own line.
+type multiError []error
+
+func (m multiError) Error() string {
+	msgs := make([]string, len(m))
+	for i, err := range m {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// joinErrors collects the non-nil errors in errs, returning nil if there are
+// none, the lone error if there is exactly one, or a multiError otherwise.
+// (errors.Join from the standard library would do this, but it requires Go
+// 1.20 and this package has historically supported older toolchains.)
+func joinErrors(errs []error) error {
+	var nonNil []error
+	for _, err := range errs {
+		if err != nil {
+			nonNil = append(nonNil, err)
+		}
+	}
+	switch len(nonNil) {
+	case 0:
+		return nil
+	case 1:
+		return nonNil[0]
+	default:
+		return multiError(nonNil)
+	}
+}
+
+func copyTaskFile(fs FS, t copyTask) error {
+	copySem <- struct{}{}
+	defer func() { <-copySem }()
+
+	if t.symlink {
+		return CopylinkFS(fs, t.dst, t.src)
+	}
+	return CopyfileFS(fs, t.dst, t.src)
+}
+
+// walk mirrors filepath.Walk but drives itself off fs instead of the os
+// package directly, so CopypathFS can traverse any FS implementation.
+func walk(fs FS, root string, fn func(path string, info os.FileInfo) error) error {
+	info, err := fs.Lstat(root)
+	if err != nil {
+		return err
+	}
+	return walk1(fs, root, info, fn)
+}
+
+func walk1(fs FS, path string, info os.FileInfo, fn func(path string, info os.FileInfo) error) error {
+	err := fn(path, info)
+	if err != nil || !info.IsDir() {
+		if err == filepath.SkipDir && info.IsDir() {
+			return nil
+		}
+		return err
+	}
+
+	entries, err := fs.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := walk1(fs, filepath.Join(path, entry.Name()), entry, fn); err != nil {
+			if err == filepath.SkipDir {
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
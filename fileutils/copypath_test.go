@@ -0,0 +1,105 @@
+package fileutils
+
+import (
+	"os"
+	"testing"
+)
+
+func writeMemFile(t *testing.T, fs *MemFS, name, content string) {
+	t.Helper()
+	w, err := fs.Create(name)
+	if err != nil {
+		t.Fatalf("Create(%q): %v", name, err)
+	}
+	defer w.Close()
+	if _, err := w.Write([]byte(content)); err != nil {
+		t.Fatalf("Write(%q): %v", name, err)
+	}
+}
+
+func TestCopypathFSFiltersIrrelevantFiles(t *testing.T) {
+	fs := NewMemFS()
+	fs.MkdirAll("/src/sub", 0755)
+	fs.MkdirAll("/src/testdata", 0755)
+	writeMemFile(t, fs, "/src/a.go", "package pkg")
+	writeMemFile(t, fs, "/src/a_test.go", "package pkg")
+	writeMemFile(t, fs, "/src/README.md", "docs")
+	writeMemFile(t, fs, "/src/sub/b.go", "package sub")
+	writeMemFile(t, fs, "/src/testdata/c.go", "package testdata")
+
+	if err := CopypathFS(fs, "/vendor/pkg", "/src", false); err != nil {
+		t.Fatalf("CopypathFS: %v", err)
+	}
+
+	for _, name := range []string{"/vendor/pkg/a.go", "/vendor/pkg/sub/b.go"} {
+		if _, err := fs.Stat(name); err != nil {
+			t.Errorf("expected %s to be copied: %v", name, err)
+		}
+	}
+	for _, name := range []string{"/vendor/pkg/a_test.go", "/vendor/pkg/README.md", "/vendor/pkg/testdata/c.go"} {
+		if _, err := fs.Stat(name); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be excluded, got err = %v", name, err)
+		}
+	}
+}
+
+func TestCopypathFSIncludesTestsWhenRequested(t *testing.T) {
+	fs := NewMemFS()
+	fs.MkdirAll("/src/testdata", 0755)
+	writeMemFile(t, fs, "/src/a_test.go", "package pkg")
+	writeMemFile(t, fs, "/src/testdata/c.go", "package testdata")
+
+	if err := CopypathFS(fs, "/vendor/pkg", "/src", true); err != nil {
+		t.Fatalf("CopypathFS: %v", err)
+	}
+
+	for _, name := range []string{"/vendor/pkg/a_test.go", "/vendor/pkg/testdata/c.go"} {
+		if _, err := fs.Stat(name); err != nil {
+			t.Errorf("expected %s to be copied when tests=true: %v", name, err)
+		}
+	}
+}
+
+func TestCopypathFSRemovesPartialCopyOnError(t *testing.T) {
+	fs := NewMemFS()
+	// src doesn't exist, so the walk fails immediately; CopypathFS must
+	// still attempt to clean up dst (a no-op here, but must not error).
+	if err := CopypathFS(fs, "/vendor/pkg", "/src", false); err == nil {
+		t.Fatal("expected an error copying from a nonexistent source")
+	}
+
+	// a.go copies fine, but sub/b.go can't, since /vendor/pkg/sub is a file;
+	// the a.go already written must be removed along with the rest of dst.
+	fs.MkdirAll("/src/sub", 0755)
+	writeMemFile(t, fs, "/src/a.go", "package pkg")
+	writeMemFile(t, fs, "/src/sub/b.go", "package sub")
+	fs.MkdirAll("/vendor/pkg", 0755)
+	writeMemFile(t, fs, "/vendor/pkg/sub", "not a directory")
+	if err := CopypathFS(fs, "/vendor/pkg", "/src", false); err == nil {
+		t.Fatal("expected an error copying into a blocked subdirectory")
+	}
+	if _, err := fs.Stat("/vendor/pkg/a.go"); !os.IsNotExist(err) {
+		t.Errorf("expected the partial copy to be removed, got err = %v", err)
+	}
+}
+
+func TestRemoveAllFSDoesNotFollowSymlinkedDirectories(t *testing.T) {
+	fs := NewMemFS()
+	fs.MkdirAll("/real", 0755)
+	writeMemFile(t, fs, "/real/keep.go", "package real")
+	fs.MkdirAll("/tree", 0755)
+	if err := fs.Symlink("/real", "/tree/link"); err != nil {
+		t.Fatalf("Symlink: %v", err)
+	}
+
+	if err := RemoveAllFS(fs, "/tree"); err != nil {
+		t.Fatalf("RemoveAllFS: %v", err)
+	}
+
+	if _, err := fs.Stat("/tree"); !os.IsNotExist(err) {
+		t.Errorf("expected /tree to be removed, got err = %v", err)
+	}
+	if _, err := fs.Stat("/real/keep.go"); err != nil {
+		t.Errorf("expected /real/keep.go to survive (not traversed into via symlink), got err = %v", err)
+	}
+}
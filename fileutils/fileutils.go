@@ -7,82 +7,25 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
-	"strings"
 )
 
-// https://golang.org/cmd/go/#hdr-File_types
-var goFileTypes = []string{
-	".go",
-	".c", ".h",
-	".cc", ".cpp", ".cxx", ".hh", ".hpp", ".hxx",
-	".m",
-	".s", ".S",
-	".swig", ".swigcxx",
-	".syso",
-}
-
-// Copypath copies the contents of src to dst, excluding any file that is not
-// relevant to the Go compiler.
-func Copypath(dst string, src string, tests bool) error {
-	err := filepath.Walk(src, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// https://golang.org/cmd/go/#hdr-Description_of_package_lists
-		name := filepath.Base(path)
-		if strings.HasPrefix(name, ".") ||
-			(strings.HasPrefix(name, "_") && name != "_testdata") ||
-			(!tests && name == "_testdata") ||
-			(!tests && name == "testdata") ||
-			(!tests && strings.HasSuffix(name, "_test.go")) {
-			if info.IsDir() {
-				return filepath.SkipDir
-			}
-			return nil
-		}
-
-		if info.IsDir() {
-			return nil
-		}
-
-		relevantFile := false
-		for _, ext := range goFileTypes {
-			if strings.HasSuffix(name, ext) {
-				relevantFile = true
-				break
-			}
-		}
-		if !relevantFile {
-			return nil
-		}
-
-		dst := filepath.Join(dst, path[len(src):])
-
-		if info.Mode()&os.ModeSymlink != 0 {
-			return Copylink(dst, path)
-		}
-
-		return Copyfile(dst, path)
-	})
-	if err != nil {
-		// if there was an error during copying, remove the partial copy.
-		RemoveAll(dst)
-	}
-	return err
+// Copyfile copies src to dst using DefaultFS.
+func Copyfile(dst, src string) error {
+	return CopyfileFS(DefaultFS, dst, src)
 }
 
-func Copyfile(dst, src string) error {
-	err := mkdir(filepath.Dir(dst))
+// CopyfileFS is like Copyfile but operates against fs.
+func CopyfileFS(fs FS, dst, src string) error {
+	err := mkdir(fs, filepath.Dir(dst))
 	if err != nil {
 		return fmt.Errorf("copyfile: mkdirall: %v", err)
 	}
-	r, err := os.Open(src)
+	r, err := fs.Open(src)
 	if err != nil {
 		return fmt.Errorf("copyfile: open(%q): %v", src, err)
 	}
 	defer r.Close()
-	w, err := os.Create(dst)
+	w, err := fs.Create(dst)
 	if err != nil {
 		return fmt.Errorf("copyfile: create(%q): %v", dst, err)
 	}
@@ -91,23 +34,67 @@ func Copyfile(dst, src string) error {
 	return err
 }
 
+// Copylink recreates src, a symlink, as dst using DefaultFS.
 func Copylink(dst, src string) error {
-	target, err := os.Readlink(src)
+	return CopylinkFS(DefaultFS, dst, src)
+}
+
+// CopylinkFS is like Copylink but operates against fs.
+func CopylinkFS(fs FS, dst, src string) error {
+	target, err := fs.Readlink(src)
 	if err != nil {
 		return fmt.Errorf("copylink: readlink: %v", err)
 	}
-	if err := mkdir(filepath.Dir(dst)); err != nil {
+	if err := mkdir(fs, filepath.Dir(dst)); err != nil {
 		return fmt.Errorf("copylink: mkdirall: %v", err)
 	}
-	if err := os.Symlink(target, dst); err != nil {
+	if err := fs.Symlink(target, dst); err != nil {
 		return fmt.Errorf("copylink: symlink: %v", err)
 	}
 	return nil
 }
 
-// RemoveAll removes path and any children it contains. Unlike os.RemoveAll it
-// deletes read only files on Windows.
+// RemoveAll removes path and any children it contains, using DefaultFS.
+// Unlike os.RemoveAll it deletes read only files on Windows.
 func RemoveAll(path string) error {
+	return RemoveAllFS(DefaultFS, path)
+}
+
+// RemoveAllFS is like RemoveAll but operates against fs. Against OSFS it
+// keeps the exact os.RemoveAll-based behaviour of the original
+// implementation; other FS implementations fall back to a generic
+// recursive remove built from ReadDir and Remove.
+//
+// It uses Lstat rather than Stat when deciding whether to recurse, so a
+// symlink to a directory (as Overlaypath creates) is removed as a single
+// entry rather than traversed into.
+func RemoveAllFS(fs FS, path string) error {
+	if _, ok := fs.(OSFS); ok {
+		return removeAllOS(path)
+	}
+
+	info, err := fs.Lstat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	if info.IsDir() {
+		entries, err := fs.ReadDir(path)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if err := RemoveAllFS(fs, filepath.Join(path, entry.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return fs.Remove(path)
+}
+
+func removeAllOS(path string) error {
 	if runtime.GOOS == "windows" {
 		// Simple case: if Remove works, we're done.
 		err := os.Remove(path)
@@ -130,6 +117,6 @@ func RemoveAll(path string) error {
 	return os.RemoveAll(path)
 }
 
-func mkdir(path string) error {
-	return os.MkdirAll(path, 0755)
-}
\ No newline at end of file
+func mkdir(fs FS, path string) error {
+	return fs.MkdirAll(path, 0755)
+}
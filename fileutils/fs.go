@@ -0,0 +1,85 @@
+package fileutils
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// File is the minimal file handle returned by an FS implementation. It is
+// satisfied by *os.File as well as in-memory implementations.
+type File interface {
+	io.Reader
+	io.Writer
+	io.Closer
+}
+
+// FS abstracts the filesystem operations used by this package so that the
+// copy/walk logic can be exercised against backends other than the host
+// filesystem (e.g. an in-memory tree for tests, or a billy-style overlay).
+type FS interface {
+	Open(name string) (File, error)
+	Create(name string) (File, error)
+	Stat(name string) (os.FileInfo, error)
+	Lstat(name string) (os.FileInfo, error)
+	ReadDir(dirname string) ([]os.FileInfo, error)
+	Symlink(oldname, newname string) error
+	Readlink(name string) (string, error)
+	MkdirAll(path string, perm os.FileMode) error
+	Remove(name string) error
+	Chmod(name string, mode os.FileMode) error
+
+	// Link makes newname an editable alias for oldname, for Overlaypath:
+	// a symlink on most platforms, or a hard link on Windows where
+	// creating a symlink needs a privilege most developer accounts lack.
+	Link(oldname, newname string) error
+}
+
+// DefaultFS is the FS used by the package-level helpers (Copypath, Copyfile,
+// Copylink, RemoveAll) when no explicit FS is given. It talks to the host
+// filesystem via the os package.
+var DefaultFS FS = OSFS{}
+
+// OSFS implements FS on top of the os package, preserving the behaviour the
+// package had before FS was introduced.
+type OSFS struct{}
+
+func (OSFS) Open(name string) (File, error) {
+	return os.Open(name)
+}
+
+func (OSFS) Create(name string) (File, error) {
+	return os.Create(name)
+}
+
+func (OSFS) Stat(name string) (os.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (OSFS) Lstat(name string) (os.FileInfo, error) {
+	return os.Lstat(name)
+}
+
+func (OSFS) ReadDir(dirname string) ([]os.FileInfo, error) {
+	return ioutil.ReadDir(dirname)
+}
+
+func (OSFS) Symlink(oldname, newname string) error {
+	return os.Symlink(oldname, newname)
+}
+
+func (OSFS) Readlink(name string) (string, error) {
+	return os.Readlink(name)
+}
+
+func (OSFS) MkdirAll(path string, perm os.FileMode) error {
+	return os.MkdirAll(path, perm)
+}
+
+func (OSFS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+func (OSFS) Chmod(name string, mode os.FileMode) error {
+	return os.Chmod(name, mode)
+}
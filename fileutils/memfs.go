@@ -0,0 +1,304 @@
+package fileutils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemFS is an in-memory FS implementation. It exists so the copy/walk logic
+// in this package can be exercised in tests without touching disk, and as a
+// starting point for alternative (e.g. overlay) backends.
+//
+// The zero value is ready to use.
+type MemFS struct {
+	mu   sync.Mutex
+	root *memNode
+}
+
+type memNode struct {
+	name     string
+	mode     os.FileMode
+	linkDest string // non-empty for symlinks
+	data     []byte
+	modTime  time.Time
+	children map[string]*memNode // non-nil for directories
+}
+
+func (n *memNode) isDir() bool {
+	return n.children != nil
+}
+
+func (n *memNode) isSymlink() bool {
+	return n.linkDest != ""
+}
+
+// memFileInfo adapts a memNode to os.FileInfo.
+type memFileInfo struct{ n *memNode }
+
+func (fi memFileInfo) Name() string { return fi.n.name }
+func (fi memFileInfo) Size() int64  { return int64(len(fi.n.data)) }
+func (fi memFileInfo) Mode() os.FileMode {
+	mode := fi.n.mode
+	if fi.n.isDir() {
+		mode |= os.ModeDir
+	}
+	if fi.n.isSymlink() {
+		mode |= os.ModeSymlink
+	}
+	return mode
+}
+func (fi memFileInfo) ModTime() time.Time { return fi.n.modTime }
+func (fi memFileInfo) IsDir() bool        { return fi.n.isDir() }
+func (fi memFileInfo) Sys() interface{}   { return nil }
+
+// NewMemFS returns an initialized MemFS. Using the zero value directly also
+// works; this constructor exists for parity with other FS implementations.
+func NewMemFS() *MemFS {
+	return &MemFS{root: &memNode{name: "/", mode: 0755, children: map[string]*memNode{}}}
+}
+
+func clean(name string) string {
+	return path.Clean(strings.ReplaceAll(name, `\`, "/"))
+}
+
+// lookup walks to the node at name, optionally following a trailing symlink.
+func (fs *MemFS) lookup(name string, followLast bool) (*memNode, error) {
+	if fs.root == nil {
+		fs.root = &memNode{name: "/", mode: 0755, children: map[string]*memNode{}}
+	}
+	name = clean(name)
+	if name == "." || name == "/" {
+		return fs.root, nil
+	}
+	parts := strings.Split(strings.TrimPrefix(name, "/"), "/")
+	cur := fs.root
+	for i, part := range parts {
+		if !cur.isDir() {
+			return nil, &os.PathError{Op: "open", Path: name, Err: fmt.Errorf("not a directory")}
+		}
+		child, ok := cur.children[part]
+		if !ok {
+			return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+		}
+		last := i == len(parts)-1
+		if child.isSymlink() && (followLast || !last) {
+			target := child.linkDest
+			if !path.IsAbs(target) {
+				target = path.Join(path.Dir(path.Join("/", strings.Join(parts[:i+1], "/"))), target)
+			}
+			resolved, err := fs.lookup(target, true)
+			if err != nil {
+				return nil, err
+			}
+			child = resolved
+		}
+		cur = child
+	}
+	return cur, nil
+}
+
+func (fs *MemFS) mkdirAll(name string) (*memNode, error) {
+	if fs.root == nil {
+		fs.root = &memNode{name: "/", mode: 0755, children: map[string]*memNode{}}
+	}
+	name = clean(name)
+	if name == "." || name == "/" {
+		return fs.root, nil
+	}
+	parts := strings.Split(strings.TrimPrefix(name, "/"), "/")
+	cur := fs.root
+	for _, part := range parts {
+		child, ok := cur.children[part]
+		if !ok {
+			child = &memNode{name: part, mode: 0755, children: map[string]*memNode{}, modTime: time.Now()}
+			cur.children[part] = child
+		} else if !child.isDir() {
+			return nil, &os.PathError{Op: "mkdir", Path: name, Err: fmt.Errorf("not a directory")}
+		}
+		cur = child
+	}
+	return cur, nil
+}
+
+func (fs *MemFS) split(name string) (dir *memNode, base string, err error) {
+	name = clean(name)
+	base = path.Base(name)
+	dir, err = fs.lookup(path.Dir(name), true)
+	return dir, base, err
+}
+
+type memFile struct {
+	buf    *bytes.Buffer
+	reader *bytes.Reader
+}
+
+func (f *memFile) Read(p []byte) (int, error) {
+	if f.reader == nil {
+		return 0, fmt.Errorf("memfile: not open for reading")
+	}
+	return f.reader.Read(p)
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	if f.buf == nil {
+		return 0, fmt.Errorf("memfile: not open for writing")
+	}
+	return f.buf.Write(p)
+}
+
+func (f *memFile) Close() error { return nil }
+
+func (fs *MemFS) Open(name string) (File, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	n, err := fs.lookup(name, true)
+	if err != nil {
+		return nil, err
+	}
+	if n.isDir() {
+		return nil, &os.PathError{Op: "open", Path: name, Err: fmt.Errorf("is a directory")}
+	}
+	return &memFile{reader: bytes.NewReader(n.data)}, nil
+}
+
+func (fs *MemFS) Create(name string) (File, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	dir, base, err := fs.split(name)
+	if err != nil {
+		return nil, err
+	}
+	buf := &bytes.Buffer{}
+	n := &memNode{name: base, mode: 0644, modTime: time.Now()}
+	dir.children[base] = n
+	return &memCreateFile{buf: buf, node: n}, nil
+}
+
+// memCreateFile updates node.data on every Write (Close is a no-op) so that
+// Stat/Open immediately after a Write see the written content.
+type memCreateFile struct {
+	buf  *bytes.Buffer
+	node *memNode
+}
+
+func (f *memCreateFile) Read(p []byte) (int, error) {
+	return 0, fmt.Errorf("memfile: not open for reading")
+}
+
+func (f *memCreateFile) Write(p []byte) (int, error) {
+	n, err := f.buf.Write(p)
+	f.node.data = f.buf.Bytes()
+	return n, err
+}
+
+func (f *memCreateFile) Close() error { return nil }
+
+func (fs *MemFS) Stat(name string) (os.FileInfo, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	n, err := fs.lookup(name, true)
+	if err != nil {
+		return nil, err
+	}
+	return memFileInfo{n}, nil
+}
+
+func (fs *MemFS) Lstat(name string) (os.FileInfo, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	n, err := fs.lookup(name, false)
+	if err != nil {
+		return nil, err
+	}
+	return memFileInfo{n}, nil
+}
+
+func (fs *MemFS) ReadDir(dirname string) ([]os.FileInfo, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	n, err := fs.lookup(dirname, true)
+	if err != nil {
+		return nil, err
+	}
+	if !n.isDir() {
+		return nil, &os.PathError{Op: "readdir", Path: dirname, Err: fmt.Errorf("not a directory")}
+	}
+	infos := make([]os.FileInfo, 0, len(n.children))
+	for _, child := range n.children {
+		infos = append(infos, memFileInfo{child})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+	return infos, nil
+}
+
+func (fs *MemFS) Symlink(oldname, newname string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	dir, base, err := fs.split(newname)
+	if err != nil {
+		return err
+	}
+	dir.children[base] = &memNode{name: base, mode: 0777, linkDest: oldname, modTime: time.Now()}
+	return nil
+}
+
+// Link makes newname an in-memory symlink to oldname. MemFS has no concept
+// of a hard link, so unlike OSFS on Windows it behaves the same as Symlink.
+func (fs *MemFS) Link(oldname, newname string) error {
+	return fs.Symlink(oldname, newname)
+}
+
+func (fs *MemFS) Readlink(name string) (string, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	n, err := fs.lookup(name, false)
+	if err != nil {
+		return "", err
+	}
+	if !n.isSymlink() {
+		return "", &os.PathError{Op: "readlink", Path: name, Err: fmt.Errorf("not a symlink")}
+	}
+	return n.linkDest, nil
+}
+
+func (fs *MemFS) MkdirAll(path string, perm os.FileMode) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	n, err := fs.mkdirAll(path)
+	if err != nil {
+		return err
+	}
+	n.mode = perm
+	return nil
+}
+
+func (fs *MemFS) Remove(name string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	dir, base, err := fs.split(name)
+	if err != nil {
+		return err
+	}
+	if _, ok := dir.children[base]; !ok {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	delete(dir.children, base)
+	return nil
+}
+
+func (fs *MemFS) Chmod(name string, mode os.FileMode) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	n, err := fs.lookup(name, true)
+	if err != nil {
+		return err
+	}
+	n.mode = mode
+	return nil
+}
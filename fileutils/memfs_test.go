@@ -0,0 +1,113 @@
+package fileutils
+
+import (
+	"io"
+	"os"
+	"testing"
+)
+
+func TestMemFSCreateAndOpen(t *testing.T) {
+	fs := NewMemFS()
+	if err := fs.MkdirAll("/pkg", 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	w, err := fs.Create("/pkg/a.go")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := w.Write([]byte("package pkg")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	w.Close()
+
+	r, err := fs.Open("/pkg/a.go")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer r.Close()
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if string(got) != "package pkg" {
+		t.Fatalf("got %q, want %q", got, "package pkg")
+	}
+}
+
+func TestMemFSReadDir(t *testing.T) {
+	fs := NewMemFS()
+	fs.MkdirAll("/pkg/sub", 0755)
+	for _, name := range []string{"/pkg/a.go", "/pkg/b.go", "/pkg/sub/c.go"} {
+		w, err := fs.Create(name)
+		if err != nil {
+			t.Fatalf("Create(%q): %v", name, err)
+		}
+		w.Close()
+	}
+
+	entries, err := fs.ReadDir("/pkg")
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	want := []string{"a.go", "b.go", "sub"}
+	if len(names) != len(want) {
+		t.Fatalf("ReadDir returned %v, want %v", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("ReadDir returned %v, want %v", names, want)
+		}
+	}
+}
+
+func TestMemFSSymlink(t *testing.T) {
+	fs := NewMemFS()
+	fs.MkdirAll("/pkg", 0755)
+	w, _ := fs.Create("/pkg/a.go")
+	w.Close()
+	if err := fs.Symlink("/pkg/a.go", "/pkg/b.go"); err != nil {
+		t.Fatalf("Symlink: %v", err)
+	}
+
+	target, err := fs.Readlink("/pkg/b.go")
+	if err != nil {
+		t.Fatalf("Readlink: %v", err)
+	}
+	if target != "/pkg/a.go" {
+		t.Fatalf("Readlink returned %q, want %q", target, "/pkg/a.go")
+	}
+
+	// Stat follows the symlink through to the regular file it points at.
+	info, err := fs.Stat("/pkg/b.go")
+	if err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		t.Fatalf("Stat followed mode = %v, want no symlink bit", info.Mode())
+	}
+
+	// Lstat does not follow it.
+	linfo, err := fs.Lstat("/pkg/b.go")
+	if err != nil {
+		t.Fatalf("Lstat: %v", err)
+	}
+	if linfo.Mode()&os.ModeSymlink == 0 {
+		t.Fatalf("Lstat mode = %v, want symlink bit set", linfo.Mode())
+	}
+}
+
+func TestMemFSRemove(t *testing.T) {
+	fs := NewMemFS()
+	w, _ := fs.Create("/a.go")
+	w.Close()
+	if err := fs.Remove("/a.go"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := fs.Stat("/a.go"); !os.IsNotExist(err) {
+		t.Fatalf("Stat after Remove: got %v, want IsNotExist", err)
+	}
+}
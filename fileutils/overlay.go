@@ -0,0 +1,51 @@
+package fileutils
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// Overlaypath links the Go-relevant files under src into dst instead of
+// copying their contents, mirroring the technique used by go's overlayDir
+// bootstrap helper. This lets a package being actively edited in $GOPATH
+// show up in the vendor tree without re-running gvt. It operates against
+// DefaultFS, i.e. the host filesystem.
+//
+// On most platforms dst entries are symlinks back into src; on Windows,
+// where creating symlinks requires an elevated privilege most developers
+// don't have, dst entries are hard links instead.
+func Overlaypath(dst string, src string, tests bool) error {
+	return OverlaypathFS(DefaultFS, dst, src, tests)
+}
+
+// OverlaypathFS is like Overlaypath but operates against fs.
+func OverlaypathFS(fs FS, dst string, src string, tests bool) error {
+	tasks, err := enumerateCopyTasks(fs, dst, src, tests)
+	if err != nil {
+		RemoveAllFS(fs, dst)
+		return err
+	}
+	for _, t := range tasks {
+		if err := overlayFile(fs, t.dst, t.src); err != nil {
+			RemoveAllFS(fs, dst)
+			return err
+		}
+	}
+	return nil
+}
+
+func overlayFile(fs FS, dst, src string) error {
+	// The parent directory must exist before the link is created; neither
+	// the symlink nor the Windows hard link creates it.
+	if err := mkdir(fs, filepath.Dir(dst)); err != nil {
+		return fmt.Errorf("overlaypath: mkdirall: %v", err)
+	}
+	abs, err := filepath.Abs(src)
+	if err != nil {
+		return fmt.Errorf("overlaypath: abs(%q): %v", src, err)
+	}
+	if err := fs.Link(abs, dst); err != nil {
+		return fmt.Errorf("overlaypath: link(%q, %q): %v", dst, abs, err)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package fileutils
+
+import "testing"
+
+func TestOverlaypathFSLinksBackToSource(t *testing.T) {
+	fs := NewMemFS()
+	fs.MkdirAll("/src/pkg", 0755)
+	writeMemFile(t, fs, "/src/pkg/a.go", "package pkg")
+	writeMemFile(t, fs, "/src/pkg/a_test.go", "package pkg")
+
+	if err := OverlaypathFS(fs, "/vendor/pkg", "/src/pkg", false); err != nil {
+		t.Fatalf("OverlaypathFS: %v", err)
+	}
+
+	target, err := fs.Readlink("/vendor/pkg/a.go")
+	if err != nil {
+		t.Fatalf("Readlink: %v", err)
+	}
+	if target != "/src/pkg/a.go" {
+		t.Fatalf("Readlink returned %q, want %q", target, "/src/pkg/a.go")
+	}
+
+	if _, err := fs.Stat("/vendor/pkg/a_test.go"); err == nil {
+		t.Fatal("expected a_test.go to be excluded by the tests=false filter")
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package fileutils
+
+import "os"
+
+// Link makes newname a symlink pointing at oldname.
+func (OSFS) Link(oldname, newname string) error {
+	return os.Symlink(oldname, newname)
+}
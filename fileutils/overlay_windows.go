@@ -0,0 +1,12 @@
+//go:build windows
+
+package fileutils
+
+import "os"
+
+// Link makes newname a hard link to oldname. Plain symlinks are avoided
+// here because creating them on Windows requires
+// SeCreateSymbolicLinkPrivilege, which most developer accounts don't have.
+func (OSFS) Link(oldname, newname string) error {
+	return os.Link(oldname, newname)
+}
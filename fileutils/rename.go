@@ -0,0 +1,88 @@
+package fileutils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Rename moves src to dst, preferring a plain os.Rename. If src and dst live
+// on different filesystems/devices os.Rename fails with EXDEV (common when
+// GOPATH and the working tree are on different mounts, or under container
+// overlays); in that case Rename falls back to recursively copying every
+// entry under src to dst, unfiltered, and then removing src.
+func Rename(dst, src string) error {
+	err := os.Rename(src, dst)
+	if err == nil {
+		return nil
+	}
+	if !isCrossDeviceRename(err) {
+		return fmt.Errorf("rename: %v", err)
+	}
+	if err := copyTreeFS(DefaultFS, dst, src); err != nil {
+		RemoveAll(dst)
+		return fmt.Errorf("rename: cross-device copy: %v", err)
+	}
+	if err := RemoveAll(src); err != nil {
+		return fmt.Errorf("rename: cross-device cleanup: %v", err)
+	}
+	return nil
+}
+
+// copyTreeFS recursively copies every entry under src to dst, with no
+// Go-file-relevance filtering. Unlike CopypathFS, which exists to stage a
+// vendor tree, this is used to move whole directories as-is (e.g. Rename's
+// cross-device fallback), so README files, LICENSE files, and anything else
+// that happens to live alongside the Go sources must survive the copy.
+func copyTreeFS(fs FS, dst string, src string) error {
+	return walk(fs, src, func(path string, info os.FileInfo) error {
+		d := filepath.Join(dst, path[len(src):])
+		switch {
+		case info.Mode()&os.ModeSymlink != 0:
+			return CopylinkFS(fs, d, path)
+		case info.IsDir():
+			return mkdir(fs, d)
+		default:
+			return CopyfileFS(fs, d, path)
+		}
+	})
+}
+
+// UpdatePath refreshes dst with the contents of src by staging the copy into
+// a sibling temp directory and then atomically renaming it into place, so a
+// build that runs concurrently with (or is interrupted during) an update
+// never observes a half-populated dst. The previous contents of dst are only
+// removed once the rename has succeeded.
+func UpdatePath(dst string, src string, tests bool) error {
+	tmp := filepath.Join(filepath.Dir(dst), ".tmp-"+filepath.Base(dst))
+	if err := RemoveAll(tmp); err != nil {
+		return fmt.Errorf("updatepath: clean stage: %v", err)
+	}
+	if err := Copypath(tmp, src, tests); err != nil {
+		return fmt.Errorf("updatepath: stage: %v", err)
+	}
+
+	old := dst + ".old"
+	haveOld := false
+	if _, err := os.Lstat(dst); err == nil {
+		if err := Rename(old, dst); err != nil {
+			RemoveAll(tmp)
+			return fmt.Errorf("updatepath: move aside previous tree: %v", err)
+		}
+		haveOld = true
+	}
+
+	if err := Rename(dst, tmp); err != nil {
+		if haveOld {
+			Rename(dst, old)
+		}
+		return fmt.Errorf("updatepath: swap in staged tree: %v", err)
+	}
+
+	if haveOld {
+		if err := RemoveAll(old); err != nil {
+			return fmt.Errorf("updatepath: remove previous tree: %v", err)
+		}
+	}
+	return nil
+}
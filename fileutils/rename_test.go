@@ -0,0 +1,70 @@
+package fileutils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// copyTreeFS is used by Rename's cross-device fallback, which can't easily
+// be forced from a test; exercise it directly instead to make sure it
+// preserves files that CopypathFS would filter out (its own filter would be
+// a regression: see Rename's doc comment).
+func TestCopyTreeFSPreservesNonGoFiles(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+	if err := os.MkdirAll(src, 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "a.go"), []byte("package pkg"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "README.md"), []byte("hello"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	if err := copyTreeFS(DefaultFS, dst, src); err != nil {
+		t.Fatalf("copyTreeFS: %v", err)
+	}
+
+	for _, name := range []string{"a.go", "README.md"} {
+		if _, err := os.Stat(filepath.Join(dst, name)); err != nil {
+			t.Errorf("expected %s to survive an unfiltered tree copy: %v", name, err)
+		}
+	}
+}
+
+func TestUpdatePath(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "vendor", "pkg")
+	if err := os.MkdirAll(src, 0755); err != nil {
+		t.Fatalf("MkdirAll: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(src, "a.go"), []byte("package pkg"), 0644); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+
+	if err := UpdatePath(dst, src, false); err != nil {
+		t.Fatalf("UpdatePath (initial): %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "a.go")); err != nil {
+		t.Fatalf("expected a.go after initial UpdatePath: %v", err)
+	}
+
+	// Refreshing an already-populated dst must succeed too, and must not
+	// leave the staging or backup directories behind.
+	if err := UpdatePath(dst, src, false); err != nil {
+		t.Fatalf("UpdatePath (refresh): %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "a.go")); err != nil {
+		t.Fatalf("expected a.go after refresh UpdatePath: %v", err)
+	}
+	if _, err := os.Stat(dst + ".old"); !os.IsNotExist(err) {
+		t.Errorf("expected %s.old to be cleaned up, got err = %v", dst, err)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(dst), ".tmp-pkg")); !os.IsNotExist(err) {
+		t.Errorf("expected the staging directory to be cleaned up, got err = %v", err)
+	}
+}
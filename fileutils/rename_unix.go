@@ -0,0 +1,19 @@
+//go:build !windows
+
+package fileutils
+
+import (
+	"os"
+	"syscall"
+)
+
+// isCrossDeviceRename reports whether err is the EXDEV error os.Rename
+// returns when src and dst are not on the same filesystem/device.
+func isCrossDeviceRename(err error) bool {
+	le, ok := err.(*os.LinkError)
+	if !ok {
+		return false
+	}
+	errno, ok := le.Err.(syscall.Errno)
+	return ok && errno == syscall.EXDEV
+}
@@ -0,0 +1,23 @@
+//go:build windows
+
+package fileutils
+
+import (
+	"os"
+	"syscall"
+)
+
+// errorNotSameDevice is ERROR_NOT_SAME_DEVICE, which the syscall package
+// doesn't define; its syscall.EXDEV is never returned by Windows itself.
+const errorNotSameDevice syscall.Errno = 17
+
+// isCrossDeviceRename reports whether err is the error os.Rename returns on
+// Windows when src and dst are not on the same volume.
+func isCrossDeviceRename(err error) bool {
+	le, ok := err.(*os.LinkError)
+	if !ok {
+		return false
+	}
+	errno, ok := le.Err.(syscall.Errno)
+	return ok && errno == errorNotSameDevice
+}